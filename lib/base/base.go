@@ -11,6 +11,7 @@ package base
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -22,6 +23,8 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 )
 
 const (
@@ -29,12 +32,37 @@ const (
 	BaseURL = "https://api.mapbox.com"
 
 	statusRateLimitExceeded = 429
+
+	// defaultTimeout bounds an individual request when no HTTPClient is set
+	defaultTimeout = 30 * time.Second
+	// defaultMaxRetries bounds the number of 429 retries in the policy set by NewBase
+	defaultMaxRetries = 3
+	// defaultRetryBackoff is used when a 429 response carries no Retry-After header
+	defaultRetryBackoff = time.Second
 )
 
+// RetryPolicy controls how requests are retried after a 429 (rate limit)
+// response. The delay before each retry honors the response's Retry-After
+// header when present, backing off exponentially from Backoff otherwise.
+type RetryPolicy struct {
+	MaxRetries int
+	Backoff    time.Duration
+}
+
 // Base Mapbox API base
 type Base struct {
 	token string
 	debug bool
+
+	// HTTPClient is used for all requests; defaults to a client with a
+	// defaultTimeout deadline. Override to customise connect/read/write
+	// timeouts or transport behaviour.
+	HTTPClient *http.Client
+
+	// Retry configures the backoff applied to 429 responses. The zero value
+	// disables retries: the first 429 response is final (QueryRequest then
+	// returns ErrorAPILimitExceeded).
+	Retry RetryPolicy
 }
 
 // NewBase Create a new API base instance
@@ -43,7 +71,10 @@ func NewBase(token string) (*Base, error) {
 		return nil, errors.New("Mapbox API token not found")
 	}
 
-	b := &Base{}
+	b := &Base{
+		HTTPClient: &http.Client{Timeout: defaultTimeout},
+		Retry:      RetryPolicy{MaxRetries: defaultMaxRetries, Backoff: defaultRetryBackoff},
+	}
 
 	b.token = token
 
@@ -55,6 +86,62 @@ func (b *Base) SetDebug(debug bool) {
 	b.debug = true
 }
 
+// client returns the configured HTTPClient, falling back to a client with
+// defaultTimeout if none was set (e.g. a Base constructed as a bare struct)
+func (b *Base) client() *http.Client {
+	if b.HTTPClient == nil {
+		return &http.Client{Timeout: defaultTimeout}
+	}
+	return b.HTTPClient
+}
+
+// doWithRetry executes request, retrying on 429 per b.Retry and honoring the
+// response's Retry-After header when present
+func (b *Base) doWithRetry(ctx context.Context, request *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := b.client().Do(request.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != statusRateLimitExceeded || attempt >= b.Retry.MaxRetries {
+			return resp, nil
+		}
+
+		delay := retryDelay(resp, b.Retry.Backoff, attempt)
+		resp.Body.Close()
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		// The previous attempt consumed the request body; rewind it so a
+		// retried POST resends the same payload
+		if request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			request.Body = body
+		}
+	}
+}
+
+// retryDelay computes the backoff for a given retry attempt, preferring the
+// Retry-After header (seconds) over the exponential fallback
+func retryDelay(resp *http.Response, backoff time.Duration, attempt int) time.Duration {
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if secs, err := strconv.Atoi(ra); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	return backoff * time.Duration(1<<uint(attempt))
+}
+
 //MapboxAPIMessage simple holder for responses from MapBox
 type MapboxAPIMessage struct {
 	Message string
@@ -62,28 +149,48 @@ type MapboxAPIMessage struct {
 
 //SimpleGET for the status check
 func (b *Base) SimpleGET(url string) ([]byte, error) {
+	return b.SimpleGETCtx(context.Background(), url)
+}
+
+// SimpleGETCtx is SimpleGET with a caller-supplied context, canceling the
+// request if ctx is done before the response arrives
+func (b *Base) SimpleGETCtx(ctx context.Context, url string) ([]byte, error) {
 	url = fmt.Sprintf("%s/%s?access_token=%s", BaseURL, url, b.token)
-	response, err := http.Get(url)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := b.doWithRetry(ctx, request)
 	if err != nil {
 		return nil, err
 	}
+	defer response.Body.Close()
+
 	body, _ := ioutil.ReadAll(response.Body)
 	return body, nil
-
 }
 
 //PostRequest sends a simple json/application post request
 func (b *Base) PostRequest(postURL string, data []byte) ([]byte, error) {
-	postURL = fmt.Sprintf("%s/%s?access_token=%s", BaseURL, postURL, b.token)
+	return b.PostRequestCtx(context.Background(), postURL, data)
+}
 
-	request, err := http.NewRequest(http.MethodPost, postURL, bytes.NewBuffer(data))
+// PostRequestCtx is PostRequest with a caller-supplied context, canceling the
+// request if ctx is done before the response arrives
+func (b *Base) PostRequestCtx(ctx context.Context, postURL string, data []byte) ([]byte, error) {
+	postURL = fmt.Sprintf("%s/%s?access_token=%s", BaseURL, postURL, b.token)
 
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, postURL, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
 	if data != nil {
 		request.Header.Set("Content-Type", "application/json")
 	}
 
-	client := &http.Client{}
-	response, err := client.Do(request)
+	response, err := b.doWithRetry(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -91,11 +198,16 @@ func (b *Base) PostRequest(postURL string, data []byte) ([]byte, error) {
 
 	body, _ := ioutil.ReadAll(response.Body)
 	return body, nil
-
 }
 
 //PostUploadFileRequest sends multipart/form-data POST request to the mapbox api
 func (b *Base) PostUploadFileRequest(postURL string, file string, filetype string) ([]byte, error) {
+	return b.PostUploadFileRequestCtx(context.Background(), postURL, file, filetype)
+}
+
+// PostUploadFileRequestCtx is PostUploadFileRequest with a caller-supplied
+// context, allowing a large in-flight upload to be cancelled
+func (b *Base) PostUploadFileRequestCtx(ctx context.Context, postURL string, file string, filetype string) ([]byte, error) {
 
 	geoJSON, err := os.Open(file)
 	if err != nil {
@@ -113,15 +225,22 @@ func (b *Base) PostUploadFileRequest(postURL string, file string, filetype strin
 	writer.Close()
 
 	postURL = fmt.Sprintf("%s/%s/?access_token=%s", BaseURL, postURL, b.token)
-	request, err := http.NewRequest(http.MethodPost, postURL, body)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, postURL, body)
 	if err != nil {
 		return nil, err
 	}
 	request.Header.Add("Content-Type", writer.FormDataContentType())
-	client := &http.Client{}
 
-	response, err := client.Do(request)
+	response, err := b.doWithRetry(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
 	resBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
 	if response.StatusCode != http.StatusOK {
 		apiMessage := MapboxAPIMessage{}
 		messageErr := json.Unmarshal(resBody, &apiMessage)
@@ -130,10 +249,6 @@ func (b *Base) PostUploadFileRequest(postURL string, file string, filetype strin
 		}
 		return nil, fmt.Errorf("Bad Request (400) - no message")
 	}
-	if err != nil {
-		return nil, err
-	}
-	defer response.Body.Close()
 	fmt.Println(string(resBody))
 
 	return resBody, nil
@@ -141,6 +256,12 @@ func (b *Base) PostUploadFileRequest(postURL string, file string, filetype strin
 
 // QueryRequest make a get with the provided query string and return the response if successful
 func (b *Base) QueryRequest(query string, v *url.Values) (*http.Response, error) {
+	return b.QueryRequestCtx(context.Background(), query, v)
+}
+
+// QueryRequestCtx is QueryRequest with a caller-supplied context, canceling
+// the request (and any pending 429 retry backoff) if ctx is done first
+func (b *Base) QueryRequestCtx(ctx context.Context, query string, v *url.Values) (*http.Response, error) {
 	// Add token to args
 	v.Set("access_token", b.token)
 
@@ -152,16 +273,13 @@ func (b *Base) QueryRequest(query string, v *url.Values) (*http.Response, error)
 	}
 
 	// Create request object
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
 	request.URL.RawQuery = v.Encode()
 
-	// Create client instance
-	client := &http.Client{}
-
-	resp, err := client.Do(request)
+	resp, err := b.doWithRetry(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,89 @@
+/**
+ * go-mapbox Base Module Tests
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package base
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoWithRetrySucceedsAfterRateLimit(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	b := &Base{HTTPClient: server.Client(), Retry: RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond}}
+	ctx := context.Background()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	assert.Nil(t, err)
+
+	resp, err := b.doWithRetry(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+}
+
+func TestDoWithRetryGivesUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	b := &Base{HTTPClient: server.Client(), Retry: RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond}}
+	ctx := context.Background()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	assert.Nil(t, err)
+
+	resp, err := b.doWithRetry(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	// initial attempt plus MaxRetries retries
+	assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+}
+
+func TestDoWithRetryCancelsDuringBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	b := &Base{HTTPClient: server.Client(), Retry: RetryPolicy{MaxRetries: 5, Backoff: time.Second}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	assert.Nil(t, err)
+
+	_, err = b.doWithRetry(ctx, request)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRetryDelayHonorsRetryAfterHeader(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"2"}}}
+	assert.Equal(t, 2*time.Second, retryDelay(resp, time.Second, 0))
+}
+
+func TestRetryDelayBacksOffExponentiallyWithoutRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	assert.Equal(t, time.Second, retryDelay(resp, time.Second, 0))
+	assert.Equal(t, 2*time.Second, retryDelay(resp, time.Second, 1))
+	assert.Equal(t, 4*time.Second, retryDelay(resp, time.Second, 2))
+}
@@ -0,0 +1,20 @@
+/**
+ * go-mapbox Base Module Errors
+ * Sentinel errors returned by Base's request helpers
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package base
+
+import "errors"
+
+var (
+	// ErrorAPILimitExceeded is returned when the Mapbox API rate limit has
+	// been exceeded and RetryPolicy has been exhausted (or disabled)
+	ErrorAPILimitExceeded = errors.New("Mapbox API rate limit exceeded")
+	// ErrorAPIUnauthorized is returned when the Mapbox API rejects the
+	// configured access token
+	ErrorAPIUnauthorized = errors.New("Mapbox API unauthorized, check access token")
+)
@@ -0,0 +1,273 @@
+/**
+ * go-mapbox Maps Module Region Downloader
+ * Bulk, resumable downloading of a bounding box of tiles across a zoom range,
+ * with optional mosaic export
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package maps
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ryankurte/go-mapbox/lib/base"
+)
+
+// maxDownloadRetries bounds how many times DownloadRegion retries a single
+// tile after base.QueryRequest reports a sustained rate limit, so a
+// persistently throttled endpoint can't hang a download forever
+const maxDownloadRetries = 5
+
+// DownloadOptions configures a DownloadRegion call
+type DownloadOptions struct {
+	// Retina requests @2x tiles when set
+	Retina bool
+	// Interval is the minimum delay between requests dispatched to the API
+	Interval time.Duration
+	// Workers bounds the number of tiles fetched concurrently (defaults to 1)
+	Workers int
+}
+
+// DownloadProgress reports per-zoom progress from DownloadRegion
+type DownloadProgress struct {
+	Zoom  uint64
+	Done  int
+	Total int
+}
+
+type tileCoord struct {
+	x, z, y uint64
+}
+
+// Cache is the subset of FileCache's contract DownloadRegion and
+// ExportMosaic rely on: looking up a decoded tile, storing a fetched one, and
+// enumerating the rectangle of tiles cached at a zoom.
+type Cache interface {
+	Get(x, y, z uint64) (image.Image, error)
+	Put(x, y, z uint64, img image.Image) error
+	TileRange(z uint64) (x1, y1, x2, y2 uint64, err error)
+}
+
+// DownloadRegion fetches every tile covering the bounding box defined by a and
+// b across the zoom levels [zMin, zMax] into the configured cache, skipping
+// tiles already present so interrupted downloads can be resumed. progress, if
+// non-nil, is called after every tile (successful or not) with per-zoom counters.
+// ctx stops new tiles being dispatched and cancels the backoff between
+// DownloadRegion's own per-tile retries; a tile fetch already in flight,
+// including base.QueryRequest's internal 429 retries, is not cancelled.
+func (m *Maps) DownloadRegion(ctx context.Context, a, b base.Location, zMin, zMax uint64, id MapID, format MapFormat, opts DownloadOptions, progress func(DownloadProgress)) error {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+
+	for z := zMin; z <= zMax; z++ {
+		x1, y1, x2, y2 := GetEnclosingTileIDs(a, b, z)
+		if x1 > x2 {
+			x1, x2 = x2, x1
+		}
+		if y1 > y2 {
+			y1, y2 = y2, y1
+		}
+
+		coords := make([]tileCoord, 0, (x2-x1+1)*(y2-y1+1))
+		for x := x1; x <= x2; x++ {
+			for y := y1; y <= y2; y++ {
+				coords = append(coords, tileCoord{x, z, y})
+			}
+		}
+
+		if err := m.downloadTiles(ctx, coords, id, format, opts, progress); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Maps) downloadTiles(ctx context.Context, coords []tileCoord, id MapID, format MapFormat, opts DownloadOptions, progress func(DownloadProgress)) error {
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, opts.Workers)
+		mu       sync.Mutex
+		done     int
+		firstErr error
+		limiter  *time.Ticker
+	)
+
+	total := len(coords)
+	if m.cache != nil {
+		pending := coords[:0]
+		for _, c := range coords {
+			if _, err := m.cache.Get(c.x, c.y, c.z); err == nil {
+				done++
+				if progress != nil {
+					progress(DownloadProgress{Zoom: c.z, Done: done, Total: total})
+				}
+				continue
+			}
+			pending = append(pending, c)
+		}
+		coords = pending
+	}
+
+	if opts.Interval > 0 {
+		limiter = time.NewTicker(opts.Interval)
+		defer limiter.Stop()
+	}
+
+	for _, c := range coords {
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(c tileCoord) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if limiter != nil {
+				select {
+				case <-limiter.C:
+				case <-ctx.Done():
+				}
+			}
+
+			_, _, err := m.getTileWithRetry(ctx, id, c.x, c.y, c.z, format, opts.Retina)
+
+			mu.Lock()
+			done++
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if progress != nil {
+				progress(DownloadProgress{Zoom: c.z, Done: done, Total: total})
+			}
+			mu.Unlock()
+		}(c)
+	}
+
+	wg.Wait()
+
+	if firstErr == nil {
+		return ctx.Err()
+	}
+	return firstErr
+}
+
+// getTileWithRetry fetches a single tile, backing off and retrying up to
+// maxDownloadRetries times when the API reports a sustained rate limit (i.e.
+// base.QueryRequest's own 429 retries were exhausted), or until ctx is done.
+func (m *Maps) getTileWithRetry(ctx context.Context, id MapID, x, y, z uint64, format MapFormat, retina bool) (image.Image, string, error) {
+	backoff := time.Second
+
+	for attempt := 0; ; attempt++ {
+		img, ext, err := m.GetTile(id, x, y, z, format, retina)
+		if err == nil {
+			return img, ext, nil
+		}
+		if err != base.ErrorAPILimitExceeded || attempt >= maxDownloadRetries {
+			return img, ext, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return img, ext, ctx.Err()
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// ExportMosaic stitches every cached tile at zoom z into a single image
+// written to outPath. Tiles are decoded from the cache on demand as the PNG
+// encoder walks the image row by row, so the full mosaic is never held
+// decoded in memory at once.
+func (m *Maps) ExportMosaic(z uint64, outPath string) error {
+	if m.cache == nil {
+		return fmt.Errorf("ExportMosaic requires a cache, see SetCache")
+	}
+
+	x1, y1, x2, y2, err := m.cache.TileRange(z)
+	if err != nil {
+		return err
+	}
+
+	first, err := m.cache.Get(x1, y1, z)
+	if err != nil {
+		return fmt.Errorf("export mosaic: missing tile %d/%d/%d: %w", z, x1, y1, err)
+	}
+	tileSize := first.Bounds().Dx()
+
+	mosaic := &lazyMosaic{
+		cache:    m.cache,
+		zoom:     z,
+		x1:       x1,
+		y1:       y1,
+		tileSize: tileSize,
+		width:    int(x2-x1+1) * tileSize,
+		height:   int(y2-y1+1) * tileSize,
+	}
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return png.Encode(out, mosaic)
+}
+
+// lazyMosaic implements image.Image over a grid of cached tiles, decoding
+// (and discarding) one tile row at a time as StitchTiles would, but without
+// ever materializing the full mosaic in memory.
+type lazyMosaic struct {
+	cache    Cache
+	zoom     uint64
+	x1, y1   uint64
+	tileSize int
+	width    int
+	height   int
+
+	rowTileY uint64
+	row      []image.Image
+}
+
+func (m *lazyMosaic) ColorModel() color.Model { return color.RGBAModel }
+
+func (m *lazyMosaic) Bounds() image.Rectangle {
+	return image.Rect(0, 0, m.width, m.height)
+}
+
+func (m *lazyMosaic) At(x, y int) color.Color {
+	tileY := m.y1 + uint64(y/m.tileSize)
+	tileX := m.x1 + uint64(x/m.tileSize)
+
+	if m.row == nil || tileY != m.rowTileY {
+		m.row = make([]image.Image, m.width/m.tileSize)
+		m.rowTileY = tileY
+	}
+
+	idx := int(tileX - m.x1)
+	if m.row[idx] == nil {
+		tile, err := m.cache.Get(tileX, tileY, m.zoom)
+		if err != nil {
+			return color.RGBA{}
+		}
+		m.row[idx] = tile
+	}
+
+	return m.row[idx].At(x%m.tileSize, y%m.tileSize)
+}
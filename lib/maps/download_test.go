@@ -0,0 +1,80 @@
+/**
+ * go-mapbox Maps Module Region Downloader Tests
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package maps
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubCache is an in-memory Cache backed by solid-colored tiles, used to
+// exercise lazyMosaic without touching disk or the network.
+type stubCache struct {
+	tileSize int
+	colors   map[[3]uint64]color.Color
+}
+
+func (c *stubCache) Get(x, y, z uint64) (image.Image, error) {
+	col, ok := c.colors[[3]uint64{x, y, z}]
+	if !ok {
+		return nil, fmt.Errorf("no tile at %d/%d/%d", z, x, y)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, c.tileSize, c.tileSize))
+	for px := 0; px < c.tileSize; px++ {
+		for py := 0; py < c.tileSize; py++ {
+			img.Set(px, py, col)
+		}
+	}
+	return img, nil
+}
+
+func (c *stubCache) Put(x, y, z uint64, img image.Image) error {
+	if c.colors == nil {
+		c.colors = make(map[[3]uint64]color.Color)
+	}
+	c.colors[[3]uint64{x, y, z}] = img.At(0, 0)
+	return nil
+}
+
+func (c *stubCache) TileRange(z uint64) (x1, y1, x2, y2 uint64, err error) {
+	return 0, 0, 1, 0, nil
+}
+
+func TestLazyMosaicStitchesAdjacentTiles(t *testing.T) {
+	cache := &stubCache{
+		tileSize: 2,
+		colors: map[[3]uint64]color.Color{
+			{0, 0, 0}: color.RGBA{R: 255, A: 255},
+			{1, 0, 0}: color.RGBA{B: 255, A: 255},
+		},
+	}
+
+	mosaic := &lazyMosaic{
+		cache:    cache,
+		zoom:     0,
+		tileSize: 2,
+		width:    4,
+		height:   2,
+	}
+
+	assert.Equal(t, image.Rect(0, 0, 4, 2), mosaic.Bounds())
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, mosaic.At(0, 0))
+	assert.Equal(t, color.RGBA{B: 255, A: 255}, mosaic.At(2, 0))
+}
+
+func TestLazyMosaicMissingTileReturnsZeroValue(t *testing.T) {
+	cache := &stubCache{tileSize: 2, colors: map[[3]uint64]color.Color{}}
+
+	mosaic := &lazyMosaic{cache: cache, tileSize: 2, width: 2, height: 2}
+
+	assert.Equal(t, color.RGBA{}, mosaic.At(0, 0))
+}
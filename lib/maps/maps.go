@@ -0,0 +1,180 @@
+/**
+ * go-mapbox Maps Module
+ * Fetches raster and vector tiles from the Mapbox Maps API
+ * See https://www.mapbox.com/api-documentation/#maps for API information
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package maps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/ryankurte/go-mapbox/lib/base"
+	"github.com/ryankurte/go-mapbox/lib/vectortile"
+)
+
+const (
+	apiName    = "v4"
+	defaultTileSize = 256
+)
+
+// MapID selects the Mapbox-hosted tile source requested from GetTile
+type MapID string
+
+const (
+	MapIDStreets          MapID = "mapbox.streets"
+	MapIDLight            MapID = "mapbox.light"
+	MapIDDark             MapID = "mapbox.dark"
+	MapIDOutdoors         MapID = "mapbox.outdoors"
+	MapIDSatellite        MapID = "mapbox.satellite"
+	MapIDSatelliteStreets MapID = "mapbox.streets-satellite"
+)
+
+// MapFormat selects the tile encoding requested from GetTile
+type MapFormat string
+
+const (
+	MapFormatPng    MapFormat = "png"
+	MapFormatPng32  MapFormat = "png32"
+	MapFormatPng64  MapFormat = "png64"
+	MapFormatPng128 MapFormat = "png128"
+	MapFormatPng256 MapFormat = "png256"
+	MapFormatPngRaw MapFormat = "pngraw"
+	MapFormatJpg70  MapFormat = "jpg70"
+	MapFormatJpg80  MapFormat = "jpg80"
+	MapFormatJpg90  MapFormat = "jpg90"
+)
+
+// formatExtension maps a MapFormat to the file extension used in the tile
+// request URL; MapFormatMVT resolves to the vector tile (gzipped protobuf)
+// endpoint rather than one of the raster image extensions.
+func formatExtension(format MapFormat) (string, error) {
+	switch format {
+	case MapFormatPng, MapFormatPng32, MapFormatPng64, MapFormatPng128, MapFormatPng256, MapFormatPngRaw, MapFormatJpg70, MapFormatJpg80, MapFormatJpg90:
+		return string(format), nil
+	case MapFormatMVT:
+		return "vector.pbf", nil
+	default:
+		return "", fmt.Errorf("maps: unsupported MapFormat %q", format)
+	}
+}
+
+// Maps is the base object for Map based APIs (tile fetching, downloading and serving)
+type Maps struct {
+	base  *base.Base
+	cache Cache
+}
+
+// NewMaps creates a new Maps API instance
+func NewMaps(b *base.Base) *Maps {
+	return &Maps{base: b}
+}
+
+// SetCache attaches a Cache that GetTile reads through (skipping the fetch
+// entirely on a hit) and writes through (caching every tile it fetches), so
+// that repeated or resumed DownloadRegion calls don't re-fetch tiles already
+// on disk.
+func (m *Maps) SetCache(cache Cache) {
+	m.cache = cache
+}
+
+// GetTile fetches a single tile from the Mapbox Maps API. If a Cache is
+// attached (see SetCache), it is checked first and returned on a hit. Raster
+// formats (png*/jpg*) are decoded into an image.Image; MapFormatMVT is
+// fetched as a gzip-compressed vector tile, decoded with
+// vectortile.DecodeTile and rasterized onto a blank Tile via DrawFeatures, so
+// callers always receive an image.Image regardless of format. A
+// successfully fetched tile is written back to the cache before it is
+// returned.
+func (m *Maps) GetTile(id MapID, x, y, z uint64, format MapFormat, retina bool) (image.Image, string, error) {
+	ext, err := formatExtension(format)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if m.cache != nil {
+		if img, err := m.cache.Get(x, y, z); err == nil {
+			return img, ext, nil
+		}
+	}
+
+	retinaSuffix := ""
+	if retina {
+		retinaSuffix = "@2x"
+	}
+
+	query := fmt.Sprintf("%s/%s/%d/%d/%d%s.%s", apiName, id, z, x, y, retinaSuffix, ext)
+
+	resp, err := m.base.QueryRequest(query, &url.Values{})
+	if err != nil {
+		return nil, ext, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, ext, err
+	}
+
+	var img image.Image
+	if format == MapFormatMVT {
+		img, err = decodeVectorTile(x, y, z, data)
+	} else {
+		img, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, ext, err
+	}
+
+	if m.cache != nil {
+		if err := m.cache.Put(x, y, z, img); err != nil {
+			return img, ext, err
+		}
+	}
+
+	return img, ext, nil
+}
+
+// decodeVectorTile un-gzips and decodes an MVT response, rasterizing its
+// features onto a blank Tile with a default style
+func decodeVectorTile(x, y, z uint64, data []byte) (image.Image, error) {
+	gzr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("maps: vector tile gunzip: %w", err)
+	}
+	defer gzr.Close()
+
+	raw, err := ioutil.ReadAll(gzr)
+	if err != nil {
+		return nil, fmt.Errorf("maps: vector tile gunzip: %w", err)
+	}
+
+	vt, err := vectortile.DecodeTile(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	size := uint64(defaultTileSize)
+	blank := image.NewRGBA(image.Rect(0, 0, int(size), int(size)))
+	tile := NewTile(x, y, z, size, blank)
+	tile.DrawFeatures(vt, defaultVectorStyle)
+
+	return tile, nil
+}
+
+// defaultVectorStyle renders every layer with a plain black, 1px stroke and
+// no fill when the caller doesn't need per-feature styling
+func defaultVectorStyle(layer string, feature *vectortile.Feature) DrawStyle {
+	return DrawStyle{Stroke: color.Black, Width: 1}
+}
@@ -0,0 +1,337 @@
+/**
+ * go-mapbox Maps Module Tile Server
+ * Serves locally mirrored MBTiles archives over HTTP, with a TileJSON 2.1.0
+ * endpoint for each published tileset
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package maps
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TileFormat identifies the image/data encoding of the tiles in an MBTiles archive
+type TileFormat string
+
+const (
+	// TileFormatPNG raw PNG tile data
+	TileFormatPNG TileFormat = "png"
+	// TileFormatJPG raw JPEG tile data
+	TileFormatJPG TileFormat = "jpg"
+	// TileFormatWebP raw WebP tile data
+	TileFormatWebP TileFormat = "webp"
+	// TileFormatPBF gzip compressed vector tile data
+	TileFormatPBF TileFormat = "pbf"
+)
+
+// ContentType returns the MIME type associated with a TileFormat
+func (f TileFormat) ContentType() string {
+	switch f {
+	case TileFormatPNG:
+		return "image/png"
+	case TileFormatJPG:
+		return "image/jpeg"
+	case TileFormatWebP:
+		return "image/webp"
+	case TileFormatPBF:
+		return "application/x-protobuf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// detectTileFormat inspects the magic bytes of a tile to determine its format
+func detectTileFormat(data []byte) TileFormat {
+	switch {
+	case len(data) >= 8 && data[0] == 0x89 && data[1] == 0x50 && data[2] == 0x4E && data[3] == 0x47:
+		return TileFormatPNG
+	case len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF:
+		return TileFormatJPG
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return TileFormatWebP
+	case len(data) >= 2 && data[0] == 0x1F && data[1] == 0x8B:
+		return TileFormatPBF
+	default:
+		return ""
+	}
+}
+
+// Tileset is a single MBTiles archive opened for serving
+type Tileset struct {
+	id       string
+	db       *sql.DB
+	format   TileFormat
+	metadata map[string]string
+}
+
+// OpenMBTiles opens an MBTiles (sqlite) archive per the mbtiles 1.3 spec and
+// reads its metadata table, taking the tile format from the metadata "format"
+// entry when present and otherwise detecting it from the first stored tile
+func OpenMBTiles(id, path string) (*Tileset, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", path))
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	metadata := make(map[string]string)
+	rows, err := db.Query(`SELECT name, value FROM metadata`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			rows.Close()
+			db.Close()
+			return nil, err
+		}
+		metadata[name] = value
+	}
+	rows.Close()
+
+	var data []byte
+	err = db.QueryRow(`SELECT tile_data FROM tiles LIMIT 1`).Scan(&data)
+	if err != nil && err != sql.ErrNoRows {
+		db.Close()
+		return nil, err
+	}
+	format := TileFormat(metadata["format"])
+	if format == "" {
+		format = detectTileFormat(data)
+	}
+
+	return &Tileset{id: id, db: db, format: format, metadata: metadata}, nil
+}
+
+// Close releases the underlying MBTiles archive
+func (t *Tileset) Close() error {
+	return t.db.Close()
+}
+
+// tile fetches a single tile, flipping the row from XYZ to the TMS scheme
+// used by the mbtiles spec, returning (nil, nil) when the tile is absent
+func (t *Tileset) tile(z, x, y uint64) ([]byte, error) {
+	tmsY := (uint64(1) << z) - 1 - y
+
+	var data []byte
+	err := t.db.QueryRow(
+		`SELECT tile_data FROM tiles WHERE zoom_level = ? AND tile_column = ? AND tile_row = ?`,
+		z, x, tmsY,
+	).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// tileJSON assembles a TileJSON 2.1.0 document for the tileset, rooted at publicURL
+func (t *Tileset) tileJSON(publicURL string) map[string]interface{} {
+	doc := map[string]interface{}{
+		"tilejson": "2.1.0",
+		"id":       t.id,
+		"scheme":   "xyz",
+		"tiles":    []string{fmt.Sprintf("%s/%s/{z}/{x}/{y}.%s", publicURL, t.id, t.format)},
+		"format":   string(t.format),
+	}
+	if name, ok := t.metadata["name"]; ok {
+		doc["name"] = name
+	}
+	if desc, ok := t.metadata["description"]; ok {
+		doc["description"] = desc
+	}
+	if attribution, ok := t.metadata["attribution"]; ok {
+		doc["attribution"] = attribution
+	}
+	if minzoom, ok := t.metadata["minzoom"]; ok {
+		if v, err := strconv.Atoi(minzoom); err == nil {
+			doc["minzoom"] = v
+		}
+	}
+	if maxzoom, ok := t.metadata["maxzoom"]; ok {
+		if v, err := strconv.Atoi(maxzoom); err == nil {
+			doc["maxzoom"] = v
+		}
+	}
+	if bounds, ok := t.metadata["bounds"]; ok {
+		parts := strings.Split(bounds, ",")
+		if len(parts) == 4 {
+			b := make([]float64, 4)
+			ok := true
+			for i, p := range parts {
+				v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+				if err != nil {
+					ok = false
+					break
+				}
+				b[i] = v
+			}
+			if ok {
+				doc["bounds"] = b
+			}
+		}
+	}
+	if center, ok := t.metadata["center"]; ok {
+		parts := strings.Split(center, ",")
+		c := make([]float64, 0, len(parts))
+		for _, p := range parts {
+			if v, err := strconv.ParseFloat(strings.TrimSpace(p), 64); err == nil {
+				c = append(c, v)
+			}
+		}
+		if len(c) > 0 {
+			doc["center"] = c
+		}
+	}
+	return doc
+}
+
+// TileServer serves one or more MBTiles archives over HTTP
+type TileServer struct {
+	prefix    string
+	publicURL string
+	tilesets  map[string]*Tileset
+}
+
+// NewTileServer creates a TileServer mounted at prefix (e.g. "/tiles"), using
+// publicURL as the hostname embedded in TileJSON responses so absolute tile
+// URLs resolve correctly behind a reverse proxy
+func NewTileServer(prefix, publicURL string) *TileServer {
+	return &TileServer{
+		prefix:    strings.TrimRight(prefix, "/"),
+		publicURL: strings.TrimRight(publicURL, "/"),
+		tilesets:  make(map[string]*Tileset),
+	}
+}
+
+// Register publishes a Tileset under the server
+func (s *TileServer) Register(t *Tileset) {
+	s.tilesets[t.id] = t
+}
+
+// NewFileServiceSet walks dir for *.mbtiles archives and registers each with
+// the returned TileServer under an id matching the file name (sans extension)
+func NewFileServiceSet(dir, prefix, publicURL string) (*TileServer, error) {
+	s := NewTileServer(prefix, publicURL)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".mbtiles" {
+			continue
+		}
+		id := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		tileset, err := OpenMBTiles(id, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("mbtiles open %s: %w", entry.Name(), err)
+		}
+		s.Register(tileset)
+	}
+
+	return s, nil
+}
+
+// ServeHTTP routes requests under the server's prefix to the list, TileJSON
+// and tile handlers
+func (s *TileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, s.prefix)
+	path = strings.TrimPrefix(path, "/")
+	parts := strings.Split(path, "/")
+
+	if path == "" {
+		s.listTilesets(w, r)
+		return
+	}
+
+	// A bare id serves the same TileJSON document as id.json, so clients
+	// don't need to know the suffix is optional.
+	if len(parts) == 1 {
+		id := strings.TrimSuffix(parts[0], ".json")
+		s.serveTileJSON(w, r, id)
+		return
+	}
+
+	if len(parts) == 4 {
+		s.serveTile(w, r, parts[0], parts[1], parts[2], parts[3])
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+func (s *TileServer) listTilesets(w http.ResponseWriter, r *http.Request) {
+	ids := make([]string, 0, len(s.tilesets))
+	for id := range s.tilesets {
+		ids = append(ids, id)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ids)
+}
+
+func (s *TileServer) serveTileJSON(w http.ResponseWriter, r *http.Request, id string) {
+	tileset, ok := s.tilesets[id]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tileset.tileJSON(s.publicURL + s.prefix))
+}
+
+func (s *TileServer) serveTile(w http.ResponseWriter, r *http.Request, id, zs, xs, yext string) {
+	tileset, ok := s.tilesets[id]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	ys := yext
+	if idx := strings.LastIndex(yext, "."); idx != -1 {
+		ys = yext[:idx]
+	}
+
+	z, zErr := strconv.ParseUint(zs, 10, 64)
+	x, xErr := strconv.ParseUint(xs, 10, 64)
+	y, yErr := strconv.ParseUint(ys, 10, 64)
+	if zErr != nil || xErr != nil || yErr != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := tileset.tile(z, x, y)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if data == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", tileset.format.ContentType())
+	if tileset.format == TileFormatPBF {
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	w.Write(data)
+}
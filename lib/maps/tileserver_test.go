@@ -0,0 +1,91 @@
+/**
+ * go-mapbox Maps Module Tile Server Tests
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package maps
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestMBTiles builds a minimal single-tile MBTiles archive per the 1.3
+// spec, storing its one tile at the TMS row for (x, y, z).
+func newTestMBTiles(t *testing.T, path string, x, y, z uint64, tileData []byte) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path)
+	assert.Nil(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE metadata (name TEXT, value TEXT)`)
+	assert.Nil(t, err)
+	_, err = db.Exec(`CREATE TABLE tiles (zoom_level INTEGER, tile_column INTEGER, tile_row INTEGER, tile_data BLOB)`)
+	assert.Nil(t, err)
+
+	_, err = db.Exec(`INSERT INTO metadata (name, value) VALUES ('name', 'test'), ('minzoom', '0'), ('maxzoom', '4')`)
+	assert.Nil(t, err)
+
+	tmsY := (uint64(1) << z) - 1 - y
+	_, err = db.Exec(`INSERT INTO tiles (zoom_level, tile_column, tile_row, tile_data) VALUES (?, ?, ?, ?)`, z, x, tmsY, tileData)
+	assert.Nil(t, err)
+}
+
+func TestOpenMBTiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mbtiles")
+	pngMagic := []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}
+	newTestMBTiles(t, path, 1, 2, 3, pngMagic)
+
+	tileset, err := OpenMBTiles("test", path)
+	assert.Nil(t, err)
+	defer tileset.Close()
+
+	assert.Equal(t, TileFormatPNG, tileset.format)
+	assert.Equal(t, "test", tileset.metadata["name"])
+}
+
+func TestTilesetTileLookupFlipsRow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mbtiles")
+	data := []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}
+	newTestMBTiles(t, path, 1, 2, 3, data)
+
+	tileset, err := OpenMBTiles("test", path)
+	assert.Nil(t, err)
+	defer tileset.Close()
+
+	found, err := tileset.tile(3, 1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, data, found)
+
+	missing, err := tileset.tile(3, 1, 5)
+	assert.Nil(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestDetectTileFormat(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want TileFormat
+	}{
+		{"png", []byte{0x89, 0x50, 0x4E, 0x47, 0, 0, 0, 0}, TileFormatPNG},
+		{"jpg", []byte{0xFF, 0xD8, 0xFF, 0, 0}, TileFormatJPG},
+		{"webp", append([]byte("RIFF\x00\x00\x00\x00"), []byte("WEBP")...), TileFormatWebP},
+		{"pbf", []byte{0x1F, 0x8B, 0, 0}, TileFormatPBF},
+		{"unknown", []byte{0, 1, 2, 3}, TileFormat("")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.want, detectTileFormat(c.data))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/**
+ * go-mapbox Maps Module Vector Tile Drawing
+ * Rasterizes decoded vector tile features onto a Tile using the existing
+ * draw pipeline, so a streets-v11 style vector source can be overlaid without
+ * a full GL renderer
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package maps
+
+import (
+	"image/color"
+
+	"github.com/ryankurte/go-mapbox/lib/vectortile"
+)
+
+// MapFormatMVT requests Mapbox Vector Tile (protobuf) encoded tiles
+const MapFormatMVT MapFormat = "mvt"
+
+// DrawStyle describes how a vector feature should be rendered onto a tile
+type DrawStyle struct {
+	// Stroke is used for line and polygon outline features
+	Stroke color.Color
+	// Fill is used for polygon interiors; nil leaves polygons unfilled
+	Fill color.Color
+	// Width is the stroke width in pixels
+	Width int
+}
+
+// DrawFeatures rasterizes every feature of every layer in vt onto the tile,
+// scaling from the vector tile's local extent to the tile's pixel size and
+// styling each feature with styler.
+func (t *Tile) DrawFeatures(vt *vectortile.Tile, styler func(layer string, feature *vectortile.Feature) DrawStyle) {
+	for _, layer := range vt.Layers {
+		scale := float64(t.size) / float64(layer.Extent)
+
+		for _, feature := range layer.Features {
+			style := styler(layer.Name, feature)
+			t.drawFeature(feature, scale, style)
+		}
+	}
+}
+
+func (t *Tile) drawFeature(feature *vectortile.Feature, scale float64, style DrawStyle) {
+	switch feature.Type {
+	case vectortile.GeomTypePoint:
+		for _, ring := range feature.Geometry {
+			for _, p := range ring {
+				t.drawDot(scalePoint(p, scale), style)
+			}
+		}
+	case vectortile.GeomTypeLineString:
+		for _, ring := range feature.Geometry {
+			t.drawPath(scaleRing(ring, scale), style, false)
+		}
+	case vectortile.GeomTypePolygon:
+		for _, ring := range feature.Geometry {
+			t.drawPath(scaleRing(ring, scale), style, style.Fill != nil)
+		}
+	}
+}
+
+func scalePoint(p vectortile.Point, scale float64) (int, int) {
+	return int(float64(p.X) * scale), int(float64(p.Y) * scale)
+}
+
+func scaleRing(ring []vectortile.Point, scale float64) [][2]int {
+	pts := make([][2]int, len(ring))
+	for i, p := range ring {
+		x, y := scalePoint(p, scale)
+		pts[i] = [2]int{x, y}
+	}
+	return pts
+}
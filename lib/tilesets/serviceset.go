@@ -0,0 +1,239 @@
+/**
+ * go-mapbox Tilesets Module Service Set
+ * Publishes Tileset instances behind a single HTTP handler whose routing is
+ * resolved against the current set on every request, so tilesets can be
+ * added or removed from a long-running process without a restart
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package tilesets
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServiceSet holds the tilesets currently published for HTTP serving. Unlike
+// a net/http.ServeMux, routes are not baked in at construction: AddTileset,
+// RemoveTileset and ReplaceTileset may be called at any time while the
+// returned Handler is serving requests.
+type ServiceSet struct {
+	mu       sync.RWMutex
+	tilesets map[string]*Tileset
+
+	// AuthToken, when set, requires requests to carry a matching bearer token
+	AuthToken string
+}
+
+// NewServiceSet creates an empty ServiceSet
+func NewServiceSet() *ServiceSet {
+	return &ServiceSet{
+		tilesets: make(map[string]*Tileset),
+	}
+}
+
+// AddTileset publishes a new tileset under id. It returns an error if id is
+// already published; use ReplaceTileset to republish over an existing id.
+func (s *ServiceSet) AddTileset(id string, t *Tileset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tilesets[id]; exists {
+		return fmt.Errorf("tilesets: %q is already published", id)
+	}
+	s.tilesets[id] = t
+	return nil
+}
+
+// RemoveTileset unpublishes a tileset, 404ing any subsequent requests for it
+func (s *ServiceSet) RemoveTileset(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tilesets, id)
+}
+
+// ReplaceTileset atomically (re)publishes id regardless of whether it was
+// already published, e.g. once CheckJobStatus reports a new job has finished
+// publishing a tileset that was already being served.
+func (s *ServiceSet) ReplaceTileset(id string, t *Tileset) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tilesets[id] = t
+}
+
+func (s *ServiceSet) get(id string) (*Tileset, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tilesets[id]
+	return t, ok
+}
+
+// Handler returns the http.Handler for the service set, wrapped with the
+// standard gzip, CORS, (optional) bearer auth and logging middleware.
+func (s *ServiceSet) Handler() http.Handler {
+	var h http.Handler = http.HandlerFunc(s.serveHTTP)
+	for _, mw := range []func(http.Handler) http.Handler{
+		s.authMiddleware,
+		corsMiddleware,
+		gzipMiddleware,
+		loggingMiddleware,
+	} {
+		h = mw(h)
+	}
+	return h
+}
+
+func (s *ServiceSet) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	if path == "" {
+		s.listTilesets(w, r)
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	id := parts[0]
+	tileset, ok := s.get(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		s.serveTilesetInfo(w, r, id, tileset)
+	case len(parts) == 2 && parts[1] == "status":
+		s.serveStatus(w, r, tileset)
+	case len(parts) == 4:
+		s.serveTile(w, r, tileset, parts[1], parts[2], parts[3])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// listTilesets responds with the ids currently published
+func (s *ServiceSet) listTilesets(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.tilesets))
+	for id := range s.tilesets {
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ids)
+}
+
+// serveTilesetInfo responds with the Mapbox-qualified name of a published tileset
+func (s *ServiceSet) serveTilesetInfo(w http.ResponseWriter, r *http.Request, id string, tileset *Tileset) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"id":     id,
+		"source": fmt.Sprintf("%s.%s", tileset.username, tileset.tilesetID),
+	})
+}
+
+// serveStatus proxies the tileset's latest publish job status
+func (s *ServiceSet) serveStatus(w http.ResponseWriter, r *http.Request, tileset *Tileset) {
+	res, err := tileset.base.SimpleGET(tileset.postURL() + "/status")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	status := &StatusResponse{}
+	if err := json.Unmarshal(res, status); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// serveTile proxies a single {z}/{x}/{y}.{ext} tile request through to the
+// Mapbox-hosted tileset
+func (s *ServiceSet) serveTile(w http.ResponseWriter, r *http.Request, tileset *Tileset, z, x, yext string) {
+	query := fmt.Sprintf("v4/%s.%s/%s/%s/%s", tileset.username, tileset.tilesetID, z, x, yext)
+
+	resp, err := tileset.base.QueryRequest(query, &url.Values{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	io.Copy(w, resp.Body)
+}
+
+func (s *ServiceSet) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.AuthToken == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		if auth != "Bearer "+s.AuthToken {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	writer *gzip.Writer
+}
+
+func (w gzipResponseWriter) Write(b []byte) (int, error) {
+	return w.writer.Write(b)
+}
+
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		next.ServeHTTP(gzipResponseWriter{ResponseWriter: w, writer: gz}, r)
+	})
+}
+
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("%s %s (%s)", r.Method, r.URL.Path, time.Since(start))
+	})
+}
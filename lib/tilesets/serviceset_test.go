@@ -0,0 +1,110 @@
+/**
+ * go-mapbox Tilesets Module Service Set Tests
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package tilesets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ryankurte/go-mapbox/lib/base"
+)
+
+func testTileset(t *testing.T) *Tileset {
+	t.Helper()
+	b, err := base.NewBase("test-token")
+	assert.Nil(t, err)
+	tileset := NewTileset(b)
+	tileset.SetTileset("someuser", "sometileset")
+	return tileset
+}
+
+func TestServiceSetAddRejectsDuplicateID(t *testing.T) {
+	s := NewServiceSet()
+	tileset := testTileset(t)
+
+	assert.Nil(t, s.AddTileset("parks", tileset))
+	assert.Error(t, s.AddTileset("parks", tileset))
+}
+
+func TestServiceSetReplaceOverwritesExisting(t *testing.T) {
+	s := NewServiceSet()
+	first := testTileset(t)
+	second := testTileset(t)
+	second.SetTileset("otheruser", "othertileset")
+
+	assert.Nil(t, s.AddTileset("parks", first))
+	s.ReplaceTileset("parks", second)
+
+	got, ok := s.get("parks")
+	assert.True(t, ok)
+	assert.Equal(t, second, got)
+}
+
+func TestServiceSetRemoveUnpublishes(t *testing.T) {
+	s := NewServiceSet()
+	s.AddTileset("parks", testTileset(t))
+	s.RemoveTileset("parks")
+
+	req := httptest.NewRequest(http.MethodGet, "/parks", nil)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServiceSetServesTilesetInfo(t *testing.T) {
+	s := NewServiceSet()
+	s.AddTileset("parks", testTileset(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/parks", nil)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "someuser.sometileset")
+}
+
+func TestServiceSetUnknownIDNotFound(t *testing.T) {
+	s := NewServiceSet()
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServiceSetAuthMiddlewareRejectsMissingToken(t *testing.T) {
+	s := NewServiceSet()
+	s.AuthToken = "secret"
+	s.AddTileset("parks", testTileset(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/parks", nil)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestCORSMiddlewareSetsHeaders(t *testing.T) {
+	s := NewServiceSet()
+	s.AddTileset("parks", testTileset(t))
+
+	req := httptest.NewRequest(http.MethodGet, "/parks", nil)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+}
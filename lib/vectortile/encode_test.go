@@ -0,0 +1,65 @@
+/**
+ * go-mapbox Vector Tile Module Test Helpers
+ * Minimal protobuf encoders mirroring pbReader, used only to build fixtures
+ * for TestDecodeTileRoundTrip
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package vectortile
+
+import "testing"
+
+func encodeVarint(v uint64) []byte {
+	var out []byte
+	for v >= 0x80 {
+		out = append(out, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(out, byte(v))
+}
+
+func encodeTag(field, wireType int) []byte {
+	return encodeVarint(uint64(field<<3 | wireType))
+}
+
+func encodeLengthDelimited(field int, data []byte) []byte {
+	out := encodeTag(field, wireLengthDelimited)
+	out = append(out, encodeVarint(uint64(len(data)))...)
+	return append(out, data...)
+}
+
+func encodePackedVarints(field int, values []uint32) []byte {
+	var packed []byte
+	for _, v := range values {
+		packed = append(packed, encodeVarint(uint64(v))...)
+	}
+	return encodeLengthDelimited(field, packed)
+}
+
+func encodeTestFeature(t *testing.T, geomType uint32, geometry []uint32) []byte {
+	t.Helper()
+	var out []byte
+	out = append(out, encodeTag(3, wireVarint)...)
+	out = append(out, encodeVarint(uint64(geomType))...)
+	out = append(out, encodePackedVarints(4, geometry)...)
+	return out
+}
+
+func encodeTestLayer(t *testing.T, name string, extent uint32, features [][]byte) []byte {
+	t.Helper()
+	var out []byte
+	out = append(out, encodeLengthDelimited(1, []byte(name))...)
+	for _, f := range features {
+		out = append(out, encodeLengthDelimited(2, f)...)
+	}
+	out = append(out, encodeTag(5, wireVarint)...)
+	out = append(out, encodeVarint(uint64(extent))...)
+	return out
+}
+
+func encodeTestTile(t *testing.T, layer []byte) []byte {
+	t.Helper()
+	return encodeLengthDelimited(3, layer)
+}
@@ -0,0 +1,122 @@
+/**
+ * go-mapbox Vector Tile Module Protobuf Primitives
+ * Minimal wire-format reader for the subset of protobuf used by the Mapbox
+ * Vector Tile spec (varint, length-delimited, fixed32 and fixed64 fields)
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package vectortile
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	wireVarint          = 0
+	wireFixed64         = 1
+	wireLengthDelimited = 2
+	wireFixed32         = 5
+)
+
+// pbReader walks a protobuf-encoded byte string one field at a time
+type pbReader struct {
+	data []byte
+	pos  int
+}
+
+func newPbReader(data []byte) *pbReader {
+	return &pbReader{data: data}
+}
+
+func (r *pbReader) done() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *pbReader) readVarint() (uint64, error) {
+	var result uint64
+	var shift uint
+	for {
+		if r.pos >= len(r.data) {
+			return 0, fmt.Errorf("vectortile: truncated varint")
+		}
+		b := r.data[r.pos]
+		r.pos++
+		result |= uint64(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return result, nil
+		}
+		shift += 7
+	}
+}
+
+// readTag returns the field number and wire type of the next field
+func (r *pbReader) readTag() (int, int, error) {
+	v, err := r.readVarint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(v >> 3), int(v & 0x7), nil
+}
+
+func (r *pbReader) readBytes() ([]byte, error) {
+	n, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	// Bound-check against len(r.data) before converting to int: n comes
+	// straight off the wire, and a corrupt/malicious varint near
+	// math.MaxUint64 would otherwise wrap int(n) negative and slip past a
+	// naive r.pos+int(n) comparison, panicking on the slice below.
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("vectortile: truncated length-delimited field")
+	}
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+func (r *pbReader) readFixed64() (uint64, error) {
+	if r.pos+8 > len(r.data) {
+		return 0, fmt.Errorf("vectortile: truncated fixed64")
+	}
+	v := binary.LittleEndian.Uint64(r.data[r.pos : r.pos+8])
+	r.pos += 8
+	return v, nil
+}
+
+func (r *pbReader) readFixed32() (uint32, error) {
+	if r.pos+4 > len(r.data) {
+		return 0, fmt.Errorf("vectortile: truncated fixed32")
+	}
+	v := binary.LittleEndian.Uint32(r.data[r.pos : r.pos+4])
+	r.pos += 4
+	return v, nil
+}
+
+// skip advances past a field of the given wire type without interpreting it
+func (r *pbReader) skip(wireType int) error {
+	switch wireType {
+	case wireVarint:
+		_, err := r.readVarint()
+		return err
+	case wireFixed64:
+		_, err := r.readFixed64()
+		return err
+	case wireLengthDelimited:
+		_, err := r.readBytes()
+		return err
+	case wireFixed32:
+		_, err := r.readFixed32()
+		return err
+	default:
+		return fmt.Errorf("vectortile: unsupported wire type %d", wireType)
+	}
+}
+
+// zigzagDecode undoes the zigzag encoding used for signed geometry deltas
+func zigzagDecode(n uint32) int32 {
+	return int32(n>>1) ^ -int32(n&1)
+}
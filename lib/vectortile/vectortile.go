@@ -0,0 +1,404 @@
+/**
+ * go-mapbox Vector Tile Module
+ * Decodes Mapbox Vector Tile (MVT) protobufs per the 2.1 spec into
+ * tile-local geometry, without requiring a full GL renderer
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package vectortile
+
+import (
+	"fmt"
+	"math"
+)
+
+// GeomType is the geometry kind of a Feature, per the MVT spec
+type GeomType int
+
+const (
+	// GeomTypeUnknown is the MVT default when a feature carries no geometry type
+	GeomTypeUnknown GeomType = 0
+	// GeomTypePoint is a (multi)point feature
+	GeomTypePoint GeomType = 1
+	// GeomTypeLineString is a (multi)line feature
+	GeomTypeLineString GeomType = 2
+	// GeomTypePolygon is a (multi)polygon feature
+	GeomTypePolygon GeomType = 3
+)
+
+// Point is a single tile-local coordinate, in the range [0, Layer.Extent]
+type Point struct {
+	X, Y int32
+}
+
+// Value is a tagged union holding a single MVT attribute value
+type Value struct {
+	StringValue *string
+	FloatValue  *float32
+	DoubleValue *float64
+	IntValue    *int64
+	UintValue   *uint64
+	SintValue   *int64
+	BoolValue   *bool
+}
+
+// Feature is a single MVT feature, decoded into tile-local geometry. Geometry
+// holds one slice of points per MoveTo: a line or ring for lines and
+// polygons, and for GeomTypePoint all the points of that MoveTo (so a
+// multipoint is a single slice).
+type Feature struct {
+	ID       uint64
+	Type     GeomType
+	Tags     map[string]Value
+	Geometry [][]Point
+}
+
+// Layer is a single named MVT layer
+type Layer struct {
+	Version  uint32
+	Name     string
+	Extent   uint32
+	Features []*Feature
+
+	keys   []string
+	values []Value
+}
+
+// Tile is a decoded MVT tile
+type Tile struct {
+	Layers []*Layer
+}
+
+// Layer looks up a decoded layer by name, returning nil if absent
+func (t *Tile) Layer(name string) *Layer {
+	for _, l := range t.Layers {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// DecodeTile parses a Mapbox Vector Tile protobuf into tile-local geometry
+func DecodeTile(data []byte) (*Tile, error) {
+	tile := &Tile{}
+
+	r := newPbReader(data)
+	for !r.done() {
+		field, wireType, err := r.readTag()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 3: // layers
+			raw, err := r.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			layer, err := decodeLayer(raw)
+			if err != nil {
+				return nil, err
+			}
+			tile.Layers = append(tile.Layers, layer)
+		default:
+			if err := r.skip(wireType); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return tile, nil
+}
+
+func decodeLayer(data []byte) (*Layer, error) {
+	layer := &Layer{Version: 1, Extent: 4096}
+
+	// Features reference keys/values tables that may appear before or after
+	// them in the stream, so features are decoded in a first pass and their
+	// tags resolved once the full layer has been read.
+	type rawFeature struct {
+		id     uint64
+		typ    GeomType
+		tagIdx []uint32
+		geom   []uint32
+	}
+	var rawFeatures []rawFeature
+
+	r := newPbReader(data)
+	for !r.done() {
+		field, wireType, err := r.readTag()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1: // name
+			b, err := r.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			layer.Name = string(b)
+		case 2: // features
+			b, err := r.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			rf, err := decodeRawFeature(b)
+			if err != nil {
+				return nil, err
+			}
+			rawFeatures = append(rawFeatures, rawFeature(rf))
+		case 3: // keys
+			b, err := r.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			layer.keys = append(layer.keys, string(b))
+		case 4: // values
+			b, err := r.readBytes()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeValue(b)
+			if err != nil {
+				return nil, err
+			}
+			layer.values = append(layer.values, v)
+		case 5: // extent
+			v, err := r.readVarint()
+			if err != nil {
+				return nil, err
+			}
+			layer.Extent = uint32(v)
+		case 15: // version
+			v, err := r.readVarint()
+			if err != nil {
+				return nil, err
+			}
+			layer.Version = uint32(v)
+		default:
+			if err := r.skip(wireType); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, rf := range rawFeatures {
+		tags := make(map[string]Value, len(rf.tagIdx)/2)
+		for i := 0; i+1 < len(rf.tagIdx); i += 2 {
+			keyIdx, valIdx := rf.tagIdx[i], rf.tagIdx[i+1]
+			if int(keyIdx) >= len(layer.keys) || int(valIdx) >= len(layer.values) {
+				continue
+			}
+			tags[layer.keys[keyIdx]] = layer.values[valIdx]
+		}
+
+		geometry, err := decodeGeometry(rf.typ, rf.geom)
+		if err != nil {
+			return nil, err
+		}
+
+		layer.Features = append(layer.Features, &Feature{
+			ID:       rf.id,
+			Type:     rf.typ,
+			Tags:     tags,
+			Geometry: geometry,
+		})
+	}
+
+	return layer, nil
+}
+
+type rawFeatureFields struct {
+	id     uint64
+	typ    GeomType
+	tagIdx []uint32
+	geom   []uint32
+}
+
+func decodeRawFeature(data []byte) (rawFeatureFields, error) {
+	rf := rawFeatureFields{}
+
+	r := newPbReader(data)
+	for !r.done() {
+		field, wireType, err := r.readTag()
+		if err != nil {
+			return rf, err
+		}
+		switch field {
+		case 1: // id
+			v, err := r.readVarint()
+			if err != nil {
+				return rf, err
+			}
+			rf.id = v
+		case 2: // tags (packed)
+			v, err := readPackedVarints(r)
+			if err != nil {
+				return rf, err
+			}
+			rf.tagIdx = v
+		case 3: // type
+			v, err := r.readVarint()
+			if err != nil {
+				return rf, err
+			}
+			rf.typ = GeomType(v)
+		case 4: // geometry (packed)
+			v, err := readPackedVarints(r)
+			if err != nil {
+				return rf, err
+			}
+			rf.geom = v
+		default:
+			if err := r.skip(wireType); err != nil {
+				return rf, err
+			}
+		}
+	}
+
+	return rf, nil
+}
+
+// readPackedVarints reads a length-delimited field as a packed repeated varint
+func readPackedVarints(r *pbReader) ([]uint32, error) {
+	b, err := r.readBytes()
+	if err != nil {
+		return nil, err
+	}
+	inner := newPbReader(b)
+	var out []uint32
+	for !inner.done() {
+		v, err := inner.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, uint32(v))
+	}
+	return out, nil
+}
+
+func decodeValue(data []byte) (Value, error) {
+	v := Value{}
+
+	r := newPbReader(data)
+	for !r.done() {
+		field, wireType, err := r.readTag()
+		if err != nil {
+			return v, err
+		}
+		switch field {
+		case 1: // string_value
+			b, err := r.readBytes()
+			if err != nil {
+				return v, err
+			}
+			s := string(b)
+			v.StringValue = &s
+		case 2: // float_value
+			raw, err := r.readFixed32()
+			if err != nil {
+				return v, err
+			}
+			f := math.Float32frombits(raw)
+			v.FloatValue = &f
+		case 3: // double_value
+			raw, err := r.readFixed64()
+			if err != nil {
+				return v, err
+			}
+			d := math.Float64frombits(raw)
+			v.DoubleValue = &d
+		case 4: // int_value
+			raw, err := r.readVarint()
+			if err != nil {
+				return v, err
+			}
+			i := int64(raw)
+			v.IntValue = &i
+		case 5: // uint_value
+			raw, err := r.readVarint()
+			if err != nil {
+				return v, err
+			}
+			v.UintValue = &raw
+		case 6: // sint_value
+			raw, err := r.readVarint()
+			if err != nil {
+				return v, err
+			}
+			s := int64(zigzagDecode(uint32(raw)))
+			v.SintValue = &s
+		case 7: // bool_value
+			raw, err := r.readVarint()
+			if err != nil {
+				return v, err
+			}
+			b := raw != 0
+			v.BoolValue = &b
+		default:
+			if err := r.skip(wireType); err != nil {
+				return v, err
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// decodeGeometry interprets the packed command/parameter stream described by
+// the MVT spec, producing tile-local point rings. MoveTo starts a new ring,
+// LineTo appends to the current ring, and ClosePath (polygons only) closes it.
+func decodeGeometry(typ GeomType, commands []uint32) ([][]Point, error) {
+	var rings [][]Point
+	var cur []Point
+	var x, y int32
+	i := 0
+
+	for i < len(commands) {
+		cmdInt := commands[i]
+		i++
+		id := cmdInt & 0x7
+		count := cmdInt >> 3
+
+		switch id {
+		case 1: // MoveTo
+			if len(cur) > 0 {
+				rings = append(rings, cur)
+			}
+			cur = make([]Point, 0, count)
+			for c := uint32(0); c < count; c++ {
+				if i+1 >= len(commands) {
+					return nil, fmt.Errorf("vectortile: truncated MoveTo")
+				}
+				x += zigzagDecode(commands[i])
+				y += zigzagDecode(commands[i+1])
+				i += 2
+				cur = append(cur, Point{x, y})
+			}
+		case 2: // LineTo
+			for c := uint32(0); c < count; c++ {
+				if i+1 >= len(commands) {
+					return nil, fmt.Errorf("vectortile: truncated LineTo")
+				}
+				x += zigzagDecode(commands[i])
+				y += zigzagDecode(commands[i+1])
+				i += 2
+				cur = append(cur, Point{x, y})
+			}
+		case 7: // ClosePath
+			if typ == GeomTypePolygon && len(cur) > 0 {
+				cur = append(cur, cur[0])
+			}
+		default:
+			return nil, fmt.Errorf("vectortile: unknown geometry command %d", id)
+		}
+	}
+
+	if len(cur) > 0 {
+		rings = append(rings, cur)
+	}
+
+	return rings, nil
+}
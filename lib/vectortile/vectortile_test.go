@@ -0,0 +1,71 @@
+/**
+ * go-mapbox Vector Tile Module Tests
+ *
+ * https://github.com/ryankurte/go-mapbox
+ * Copyright 2017 Ryan Kurte
+ */
+
+package vectortile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBytesRejectsOverflowingLength(t *testing.T) {
+	// A 10-byte varint encoding a length near math.MaxUint64, followed by a
+	// couple of trailing bytes it must not be allowed to slice into.
+	data := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 0x41, 0x42}
+
+	r := newPbReader(data)
+
+	assert.NotPanics(t, func() {
+		_, err := r.readBytes()
+		assert.Error(t, err)
+	})
+}
+
+func TestDecodeGeometryPoint(t *testing.T) {
+	// MoveTo(1 point), dx=3, dy=4 zigzag encoded
+	commands := []uint32{(1 << 3) | 1, 6, 8}
+
+	rings, err := decodeGeometry(GeomTypePoint, commands)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]Point{{{X: 3, Y: 4}}}, rings)
+}
+
+func TestDecodeGeometryPolygonClosesRing(t *testing.T) {
+	// MoveTo(0,0), LineTo two more points, ClosePath
+	commands := []uint32{
+		(1 << 3) | 1, 0, 0,
+		(2 << 3) | 2, 20, 0, 0, 20,
+		7,
+	}
+
+	rings, err := decodeGeometry(GeomTypePolygon, commands)
+	assert.Nil(t, err)
+	assert.Len(t, rings, 1)
+	assert.Equal(t, rings[0][0], rings[0][len(rings[0])-1])
+}
+
+func TestDecodeTileRoundTrip(t *testing.T) {
+	layer := encodeTestLayer(t, "water", 4096, [][]byte{
+		encodeTestFeature(t, uint32(GeomTypePolygon), []uint32{
+			(1 << 3) | 1, 0, 0,
+			(2 << 3) | 2, 20, 0, 0, 20,
+			7,
+		}),
+	})
+	tile := encodeTestTile(t, layer)
+
+	decoded, err := DecodeTile(tile)
+	assert.Nil(t, err)
+	assert.Len(t, decoded.Layers, 1)
+
+	l := decoded.Layer("water")
+	assert.NotNil(t, l)
+	assert.EqualValues(t, 4096, l.Extent)
+	assert.Len(t, l.Features, 1)
+	assert.Equal(t, GeomTypePolygon, l.Features[0].Type)
+}